@@ -0,0 +1,288 @@
+package yeelight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type (
+	// Power is the on/off state of a smart LED.
+	Power bool
+
+	// Effect controls how a property change is animated: abruptly (sudden)
+	// or over the given duration (smooth).
+	Effect string
+
+	// CFAction is what the bulb does once a color flow finishes.
+	CFAction int
+
+	// FlowMode selects what a single FlowExpression transitions.
+	FlowMode int
+
+	// AdjustAction is the kind of change set_adjust applies to a property.
+	AdjustAction string
+
+	// AdjustProp is the property set_adjust changes.
+	AdjustProp string
+
+	// SceneClass selects which kind of scene set_scene installs.
+	SceneClass string
+)
+
+//Power values.
+const (
+	PowerOff Power = false
+	PowerOn  Power = true
+)
+
+func (p Power) String() string {
+	if p {
+		return "on"
+	}
+	return "off"
+}
+
+//Effect values.
+const (
+	EffectSudden Effect = "sudden"
+	EffectSmooth Effect = "smooth"
+)
+
+//CFAction values.
+const (
+	CFActionRecover CFAction = 0 // return to the state before the flow started
+	CFActionStay    CFAction = 1 // stay at the state of the last flow expression
+	CFActionOff     CFAction = 2 // turn off after the flow finishes
+)
+
+//FlowMode values.
+const (
+	FlowModeColor            FlowMode = 1
+	FlowModeColorTemperature FlowMode = 2
+	FlowModeSleep            FlowMode = 7
+)
+
+//AdjustAction values.
+const (
+	AdjustIncrease AdjustAction = "increase"
+	AdjustDecrease AdjustAction = "decrease"
+	AdjustCircle   AdjustAction = "circle"
+)
+
+//AdjustProp values.
+const (
+	AdjustBright AdjustProp = "bright"
+	AdjustCT     AdjustProp = "ct"
+	AdjustColor  AdjustProp = "color"
+)
+
+//SceneClass values.
+const (
+	SceneColor        SceneClass = "color"
+	SceneHSV          SceneClass = "hsv"
+	SceneColorTemp    SceneClass = "ct"
+	SceneColorFlow    SceneClass = "cf"
+	SceneAutoDelayOff SceneClass = "auto_delay_off"
+)
+
+//FlowExpression is a single transition in a color flow: over Duration,
+//move to Value (a color temperature or RGB value depending on Mode) and
+//Brightness.
+type FlowExpression struct {
+	Duration   time.Duration
+	Mode       FlowMode
+	Value      int
+	Brightness int
+}
+
+//String renders the expression as the protocol's
+//"duration,mode,value,brightness" tuple.
+func (f FlowExpression) String() string {
+	return fmt.Sprintf("%d,%d,%d,%d", f.Duration.Milliseconds(), f.Mode, f.Value, f.Brightness)
+}
+
+//flowExpressions joins a slice of FlowExpression into the comma-separated
+//string start_cf/bg_start_cf expect.
+func flowExpressions(flow []FlowExpression) string {
+	parts := make([]string, len(flow))
+	for i, f := range flow {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func durationMillis(d time.Duration) int {
+	return int(d / time.Millisecond)
+}
+
+//SetPower switches the smart LED on or off (software managed on/off).
+func (y *Yeelight) SetPower(ctx context.Context, on Power, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "set_power", []interface{}{on.String(), string(effect), durationMillis(duration)})
+	return err
+}
+
+//SetPowerString is a thin shim over SetPower for callers still passing the
+//raw protocol "on"/"off" string.
+//
+//Deprecated: use SetPower.
+func (y *Yeelight) SetPowerString(ctx context.Context, on string) error {
+	return y.SetPower(ctx, Power(on == "on"), EffectSudden, 0)
+}
+
+//Toggle flips the smart LED between on and off.
+func (y *Yeelight) Toggle(ctx context.Context) error {
+	_, err := y.executeCommand(ctx, "toggle", nil)
+	return err
+}
+
+//SetDefault saves the current state as the default one that is restored on
+//power-up.
+func (y *Yeelight) SetDefault(ctx context.Context) error {
+	_, err := y.executeCommand(ctx, "set_default", nil)
+	return err
+}
+
+//SetName sets the device name stored on the bulb itself.
+func (y *Yeelight) SetName(ctx context.Context, name string) error {
+	_, err := y.executeCommand(ctx, "set_name", []interface{}{name})
+	return err
+}
+
+//SetCTAbx changes the color temperature, in degrees Kelvin (1700-6500).
+func (y *Yeelight) SetCTAbx(ctx context.Context, ct int, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "set_ct_abx", []interface{}{ct, string(effect), durationMillis(duration)})
+	return err
+}
+
+//SetRGB changes the color, packed as 0xRRGGBB.
+func (y *Yeelight) SetRGB(ctx context.Context, rgb uint32, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "set_rgb", []interface{}{rgb, string(effect), durationMillis(duration)})
+	return err
+}
+
+//SetHSV changes the color, as hue (0-359) and saturation (0-100).
+func (y *Yeelight) SetHSV(ctx context.Context, hue, sat int, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "set_hsv", []interface{}{hue, sat, string(effect), durationMillis(duration)})
+	return err
+}
+
+//SetScene sets the smart LED directly to a state, skipping any transition.
+//val must match what class expects, e.g. SceneColor wants (rgb, bright).
+func (y *Yeelight) SetScene(ctx context.Context, class SceneClass, val ...interface{}) error {
+	params := append([]interface{}{string(class)}, val...)
+	_, err := y.executeCommand(ctx, "set_scene", params)
+	return err
+}
+
+//SetAdjust changes a property without specifying a value, e.g. cycling
+//through color temperatures. Not supported for AdjustColor with
+//AdjustDecrease/AdjustIncrease.
+func (y *Yeelight) SetAdjust(ctx context.Context, action AdjustAction, prop AdjustProp) error {
+	_, err := y.executeCommand(ctx, "set_adjust", []interface{}{string(action), string(prop)})
+	return err
+}
+
+//CronAdd schedules the device to turn off after value minutes. typ must be
+//0 (power-off), the only type currently defined by the protocol.
+func (y *Yeelight) CronAdd(ctx context.Context, typ int, value int) error {
+	_, err := y.executeCommand(ctx, "cron_add", []interface{}{typ, value})
+	return err
+}
+
+//CronGet retrieves the cron job of the given type, if any.
+func (y *Yeelight) CronGet(ctx context.Context, typ int) ([]interface{}, error) {
+	r, err := y.executeCommand(ctx, "cron_get", []interface{}{typ})
+	if err != nil {
+		return nil, err
+	}
+	return r.Result, nil
+}
+
+//CronDel removes the cron job of the given type.
+func (y *Yeelight) CronDel(ctx context.Context, typ int) error {
+	_, err := y.executeCommand(ctx, "cron_del", []interface{}{typ})
+	return err
+}
+
+//StartCF starts a color flow: count repetitions of flow (0 repeats
+//forever), finishing with action once it stops.
+func (y *Yeelight) StartCF(ctx context.Context, count int, action CFAction, flow []FlowExpression) error {
+	_, err := y.executeCommand(ctx, "start_cf", []interface{}{count, int(action), flowExpressions(flow)})
+	return err
+}
+
+//StopCF stops a running color flow.
+func (y *Yeelight) StopCF(ctx context.Context) error {
+	_, err := y.executeCommand(ctx, "stop_cf", nil)
+	return err
+}
+
+//BgSetPower switches the background light on or off, for models with a
+//secondary background LED.
+func (y *Yeelight) BgSetPower(ctx context.Context, on Power, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "bg_set_power", []interface{}{on.String(), string(effect), durationMillis(duration)})
+	return err
+}
+
+//BgSetBright changes the background light's brightness (1-100).
+func (y *Yeelight) BgSetBright(ctx context.Context, bright int, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "bg_set_bright", []interface{}{bright, string(effect), durationMillis(duration)})
+	return err
+}
+
+//BgSetCTAbx changes the background light's color temperature.
+func (y *Yeelight) BgSetCTAbx(ctx context.Context, ct int, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "bg_set_ct_abx", []interface{}{ct, string(effect), durationMillis(duration)})
+	return err
+}
+
+//BgSetRGB changes the background light's color, packed as 0xRRGGBB.
+func (y *Yeelight) BgSetRGB(ctx context.Context, rgb uint32, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "bg_set_rgb", []interface{}{rgb, string(effect), durationMillis(duration)})
+	return err
+}
+
+//BgSetHSV changes the background light's color, as hue and saturation.
+func (y *Yeelight) BgSetHSV(ctx context.Context, hue, sat int, effect Effect, duration time.Duration) error {
+	_, err := y.executeCommand(ctx, "bg_set_hsv", []interface{}{hue, sat, string(effect), durationMillis(duration)})
+	return err
+}
+
+//BgSetScene sets the background light directly to a state.
+func (y *Yeelight) BgSetScene(ctx context.Context, class SceneClass, val ...interface{}) error {
+	params := append([]interface{}{string(class)}, val...)
+	_, err := y.executeCommand(ctx, "bg_set_scene", params)
+	return err
+}
+
+//BgSetDefault saves the background light's current state as the default.
+func (y *Yeelight) BgSetDefault(ctx context.Context) error {
+	_, err := y.executeCommand(ctx, "bg_set_default", nil)
+	return err
+}
+
+//BgStartCF starts a color flow on the background light.
+func (y *Yeelight) BgStartCF(ctx context.Context, count int, action CFAction, flow []FlowExpression) error {
+	_, err := y.executeCommand(ctx, "bg_start_cf", []interface{}{count, int(action), flowExpressions(flow)})
+	return err
+}
+
+//BgStopCF stops a running background light color flow.
+func (y *Yeelight) BgStopCF(ctx context.Context) error {
+	_, err := y.executeCommand(ctx, "bg_stop_cf", nil)
+	return err
+}
+
+//BgToggle flips the background light between on and off.
+func (y *Yeelight) BgToggle(ctx context.Context) error {
+	_, err := y.executeCommand(ctx, "bg_toggle", nil)
+	return err
+}
+
+//DevToggle flips both the main and background light at once.
+func (y *Yeelight) DevToggle(ctx context.Context) error {
+	_, err := y.executeCommand(ctx, "dev_toggle", nil)
+	return err
+}
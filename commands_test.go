@@ -0,0 +1,41 @@
+package yeelight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlowExpressionString(t *testing.T) {
+	f := FlowExpression{Duration: 500 * time.Millisecond, Mode: FlowModeColor, Value: 16711680, Brightness: 100}
+	want := "500,1,16711680,100"
+	if got := f.String(); got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestFlowExpressions(t *testing.T) {
+	flow := []FlowExpression{
+		{Duration: time.Second, Mode: FlowModeColorTemperature, Value: 3000, Brightness: 50},
+		{Duration: 2 * time.Second, Mode: FlowModeSleep, Value: 0, Brightness: 0},
+	}
+	want := "1000,2,3000,50,2000,7,0,0"
+	if got := flowExpressions(flow); got != want {
+		t.Fatalf("flowExpressions() = %q, want %q", got, want)
+	}
+}
+
+func TestDurationMillis(t *testing.T) {
+	cases := []struct {
+		d    time.Duration
+		want int
+	}{
+		{time.Second, 1000},
+		{1500 * time.Millisecond, 1500},
+		{0, 0},
+	}
+	for _, c := range cases {
+		if got := durationMillis(c.d); got != c.want {
+			t.Fatalf("durationMillis(%s) = %d, want %d", c.d, got, c.want)
+		}
+	}
+}
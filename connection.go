@@ -0,0 +1,254 @@
+package yeelight
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// reconnectMinDelay is the initial delay before redialing a dropped connection
+	reconnectMinDelay = 500 * time.Millisecond
+
+	// reconnectMaxDelay caps the exponential backoff between redial attempts
+	reconnectMaxDelay = 30 * time.Second
+)
+
+//Listen connects to the device (if not already connected) and returns the
+//channel on which NOTIFICATION events are delivered. Notifications and
+//command results flow over the same long-lived connection.
+func (y *Yeelight) Listen() (<-chan *Notification, error) {
+	return y.ListenContext(context.Background())
+}
+
+//ListenContext is like Listen but lets the caller bound how long connecting
+//to the device is allowed to take.
+func (y *Yeelight) ListenContext(ctx context.Context) (<-chan *Notification, error) {
+	if _, err := y.ensureConn(ctx); err != nil {
+		return nil, err
+	}
+	return y.notifications(), nil
+}
+
+//notifications lazily creates the notification channel.
+func (y *Yeelight) notifications() chan *Notification {
+	y.notifyOnce.Do(func() {
+		y.notifCh = make(chan *Notification)
+	})
+	return y.notifCh
+}
+
+//Close stops any in-progress reconnect loop and tears down the active
+//connection and music-mode connection, if any. A closed Yeelight cannot reconnect; further calls fail
+//with an error instead of redialing.
+func (y *Yeelight) Close() error {
+	y.closeOnce.Do(func() { close(y.closed) })
+
+	y.connMu.Lock()
+	conn := y.conn
+	y.conn = nil
+	musicConn := y.musicConn
+	y.musicConn = nil
+	y.connMu.Unlock()
+
+	if musicConn != nil {
+		musicConn.Close()
+	}
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+//ensureConn returns the current connection, dialing a new one if needed.
+func (y *Yeelight) ensureConn(ctx context.Context) (net.Conn, error) {
+	select {
+	case <-y.closed:
+		return nil, errors.New("yeelight: connection closed")
+	default:
+	}
+
+	y.connMu.Lock()
+	defer y.connMu.Unlock()
+
+	if y.conn != nil {
+		return y.conn, nil
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", y.addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s. %s", y.addr, err)
+	}
+
+	y.conn = conn
+	y.pending = make(map[int]chan *CommandResult)
+	go y.readLoop(conn)
+
+	return conn, nil
+}
+
+//readLoop demultiplexes inbound lines into notifications (no "id") and
+//command results (looked up by "id" in y.pending), analogous to a p2p
+//message dispatch loop. It redials with exponential backoff if the
+//connection drops.
+func (y *Yeelight) readLoop(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			y.dropConn(conn, err)
+			go y.reconnect()
+			return
+		}
+		y.dispatch([]byte(line))
+	}
+}
+
+//dispatch routes a single inbound line to its notification or pending
+//command result channel.
+func (y *Yeelight) dispatch(line []byte) {
+	var probe struct {
+		ID *int `json:"id"`
+	}
+	if err := json.Unmarshal(line, &probe); err != nil {
+		return
+	}
+
+	if probe.ID == nil {
+		var n Notification
+		if err := json.Unmarshal(line, &n); err != nil {
+			return
+		}
+		select {
+		case y.notifications() <- &n:
+		default:
+		}
+		return
+	}
+
+	var rs CommandResult
+	if err := json.Unmarshal(line, &rs); err != nil {
+		return
+	}
+
+	y.connMu.Lock()
+	ch, ok := y.pending[rs.ID]
+	if ok {
+		delete(y.pending, rs.ID)
+	}
+	y.connMu.Unlock()
+
+	if ok {
+		ch <- &rs
+	}
+}
+
+//dropConn closes conn and, if it is still the active one, fails every
+//command waiting on a response so callers don't block on a dead socket.
+func (y *Yeelight) dropConn(conn net.Conn, cause error) {
+	conn.Close()
+
+	y.connMu.Lock()
+	if y.conn != conn {
+		y.connMu.Unlock()
+		return
+	}
+	y.conn = nil
+	pending := y.pending
+	y.pending = nil
+	y.connMu.Unlock()
+
+	for _, ch := range pending {
+		ch <- &CommandResult{Error: &Error{Message: fmt.Sprintf("connection lost: %s", cause)}}
+	}
+}
+
+//reconnect redials the device with exponential backoff until it succeeds or
+//y is closed.
+func (y *Yeelight) reconnect() {
+	delay := reconnectMinDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-y.closed:
+			return
+		}
+		if _, err := y.ensureConn(context.Background()); err == nil {
+			return
+		}
+		delay *= 2
+		if delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+}
+
+//newID returns the next per-device command id.
+func (y *Yeelight) newID() int {
+	return int(atomic.AddUint32(&y.nextID, 1))
+}
+
+//executeCommand executes command with provided parameters
+func (y *Yeelight) executeCommand(ctx context.Context, name string, params []interface{}) (*CommandResult, error) {
+	return y.execute(ctx, &Command{ID: y.newID(), Method: name, Params: params})
+}
+
+//execute writes cmd on the persistent connection and waits for the matching
+//response to be delivered by the reader goroutine, or for ctx to be done.
+func (y *Yeelight) execute(ctx context.Context, cmd *Command) (*CommandResult, error) {
+	if mc := y.musicConnection(); mc != nil {
+		return y.executeOverMusicConn(mc, cmd)
+	}
+
+	conn, err := y.ensureConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	respCh := make(chan *CommandResult, 1)
+
+	y.connMu.Lock()
+	if y.conn != conn || y.pending == nil {
+		y.connMu.Unlock()
+		return nil, errors.New("connection lost before command could be sent")
+	}
+	y.pending[cmd.ID] = respCh
+	y.connMu.Unlock()
+
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(dl)
+	} else {
+		conn.SetWriteDeadline(time.Time{})
+	}
+
+	y.writeMu.Lock()
+	_, err = fmt.Fprint(conn, string(b)+crlf)
+	y.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("cannot send command to %s. %s", y.addr, err)
+	}
+
+	select {
+	case rs := <-respCh:
+		if rs.Error != nil {
+			return nil, fmt.Errorf("command execution error. Code: %d, Message: %s", rs.Error.Code, rs.Error.Message)
+		}
+		return rs, nil
+	case <-ctx.Done():
+		y.connMu.Lock()
+		delete(y.pending, cmd.ID)
+		y.connMu.Unlock()
+		return nil, ctx.Err()
+	}
+}
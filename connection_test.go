@@ -0,0 +1,55 @@
+package yeelight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDispatchRoutesCommandResultByID(t *testing.T) {
+	y := New(State{Address: "127.0.0.1:55443"})
+	ch := make(chan *CommandResult, 1)
+	y.pending = map[int]chan *CommandResult{7: ch}
+
+	y.dispatch([]byte(`{"id":7,"result":["ok"]}` + "\n"))
+
+	select {
+	case rs := <-ch:
+		if len(rs.Result) != 1 || rs.Result[0] != "ok" {
+			t.Fatalf("got result %+v, want [\"ok\"]", rs.Result)
+		}
+	default:
+		t.Fatal("command result was not delivered to the pending channel")
+	}
+
+	if _, ok := y.pending[7]; ok {
+		t.Fatal("pending entry for id 7 was not cleared")
+	}
+}
+
+func TestDispatchRoutesNotification(t *testing.T) {
+	y := New(State{Address: "127.0.0.1:55443"})
+	notifCh := y.notifications()
+
+	done := make(chan *Notification, 1)
+	go func() { done <- <-notifCh }()
+	time.Sleep(10 * time.Millisecond) // let the receive above start blocking
+
+	y.dispatch([]byte(`{"method":"props","params":{"power":"on"}}` + "\n"))
+
+	select {
+	case n := <-done:
+		if n.Method != "props" {
+			t.Fatalf("Method = %q, want %q", n.Method, "props")
+		}
+		if n.Params["power"] != "on" {
+			t.Fatalf("Params = %+v, want power=on", n.Params)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notification was not delivered")
+	}
+}
+
+func TestDispatchIgnoresMalformedLine(t *testing.T) {
+	y := New(State{Address: "127.0.0.1:55443"})
+	y.dispatch([]byte("not json\n"))
+}
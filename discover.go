@@ -0,0 +1,146 @@
+package yeelight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+//Discover discovers a single device in the local network via ssdp. It is a
+//convenience wrapper around DiscoverContext using context.Background().
+func Discover() (*Yeelight, error) {
+	return DiscoverContext(context.Background())
+}
+
+//DiscoverContext is like Discover but lets the caller bound how long
+//discovery is allowed to take via ctx, instead of always using the
+//package's default discovery window.
+func DiscoverContext(ctx context.Context) (*Yeelight, error) {
+	d := timeout
+	if dl, ok := ctx.Deadline(); ok {
+		d = time.Until(dl)
+	}
+	found, err := DiscoverAll(ctx, d, nil)
+	if err != nil {
+		return nil, err
+	}
+	return found[0], nil
+}
+
+//DiscoverAll discovers every Yeelight device reachable in the local network.
+//It sends the SSDP M-SEARCH request and then keeps listening, both for the
+//unicast replies to that request and for passive NOTIFY advertisements sent
+//by bulbs that power on while discovery is in progress, until d elapses or
+//ctx is done. Devices are deduplicated by their LOCATION header.
+//
+//If results is non-nil, each newly discovered device is also sent on it as
+//soon as it is found, so callers can react without waiting for the full
+//window; the channel is closed before DiscoverAll returns.
+func DiscoverAll(ctx context.Context, d time.Duration, results chan<- *Yeelight) ([]*Yeelight, error) {
+	if results != nil {
+		defer close(results)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	ssdp, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	localIP, err := GetOutboundIP(ssdpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine local address: %s", err)
+	}
+
+	// queryConn sends the M-SEARCH request and receives the bulbs' unicast replies
+	queryConn, err := net.ListenPacket("udp4", localIP+":0")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open discovery socket: %s", err)
+	}
+	defer queryConn.Close()
+
+	// mcastConn joins the ssdp multicast group to also catch passive NOTIFY
+	// advertisements from bulbs that join the network during discovery
+	mcastConn, err := net.ListenMulticastUDP("udp4", nil, ssdp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot join multicast group: %s", err)
+	}
+	defer mcastConn.Close()
+
+	if _, err := queryConn.WriteTo([]byte(discoverMSG), ssdp); err != nil {
+		return nil, fmt.Errorf("cannot send discover message: %s", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		seen  = make(map[string]bool)
+		found []*Yeelight
+	)
+
+	handle := func(raw []byte) {
+		state, err := parseState(string(raw))
+		if err != nil || state.Address == "" {
+			return
+		}
+
+		mu.Lock()
+		if seen[state.Address] {
+			mu.Unlock()
+			return
+		}
+		seen[state.Address] = true
+		y := New(*state)
+		found = append(found, y)
+		mu.Unlock()
+
+		if results != nil {
+			select {
+			case results <- y:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go readUntilDone(ctx, queryConn, handle, &wg)
+	go readUntilDone(ctx, mcastConn, handle, &wg)
+	wg.Wait()
+
+	if len(found) == 0 {
+		return nil, errors.New("no devices found")
+	}
+	return found, nil
+}
+
+//readUntilDone reads SSDP packets from conn and passes each one to handle
+//until ctx is done, at which point conn is closed to unblock the read.
+func readUntilDone(ctx context.Context, conn net.PacketConn, handle func([]byte), wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		handle(data)
+	}
+}
@@ -0,0 +1,67 @@
+package yeelight
+
+import "testing"
+
+// parseState's Address is the key DiscoverAll dedups on, so a bulb that
+// advertises twice (e.g. a unicast reply plus a NOTIFY) must parse to the
+// same address both times, and two different bulbs must parse to different
+// addresses.
+func TestParseStateExtractsAddress(t *testing.T) {
+	msg := "HTTP/1.1 200 OK\r\n" +
+		"Cache-Control: max-age=3600\r\n" +
+		"Location: yeelight://192.168.1.10:55443\r\n" +
+		"NAME: bedroom\r\n" +
+		"POWER: on\r\n" +
+		"BRIGHT: 80\r\n"
+
+	state, err := parseState(msg)
+	if err != nil {
+		t.Fatalf("parseState returned error: %s", err)
+	}
+	if state.Address != "192.168.1.10:55443" {
+		t.Fatalf("Address = %q, want %q", state.Address, "192.168.1.10:55443")
+	}
+	if state.Name != "bedroom" {
+		t.Fatalf("Name = %q, want %q", state.Name, "bedroom")
+	}
+}
+
+// Passive advertisements arrive as NOTIFY requests, not HTTP responses to
+// the M-SEARCH query, so parseState must branch on the start line instead
+// of always reading an HTTP response.
+func TestParseStateExtractsAddressFromNotify(t *testing.T) {
+	msg := "NOTIFY * HTTP/1.1\r\n" +
+		"Host: 239.255.255.250:1982\r\n" +
+		"Cache-Control: max-age=3600\r\n" +
+		"Location: yeelight://192.168.1.20:55443\r\n" +
+		"NT: wifi_bulb\r\n" +
+		"NTS: ssdp:alive\r\n" +
+		"NAME: kitchen\r\n" +
+		"POWER: on\r\n" +
+		"BRIGHT: 100\r\n"
+
+	state, err := parseState(msg)
+	if err != nil {
+		t.Fatalf("parseState returned error: %s", err)
+	}
+	if state.Address != "192.168.1.20:55443" {
+		t.Fatalf("Address = %q, want %q", state.Address, "192.168.1.20:55443")
+	}
+	if state.Name != "kitchen" {
+		t.Fatalf("Name = %q, want %q", state.Name, "kitchen")
+	}
+}
+
+func TestParseStateDifferentBulbsYieldDistinctAddresses(t *testing.T) {
+	one, err := parseState("HTTP/1.1 200 OK\r\nLocation: yeelight://10.0.0.1:55443\r\n")
+	if err != nil {
+		t.Fatalf("parseState returned error: %s", err)
+	}
+	two, err := parseState("HTTP/1.1 200 OK\r\nLocation: yeelight://10.0.0.2:55443\r\n")
+	if err != nil {
+		t.Fatalf("parseState returned error: %s", err)
+	}
+	if one.Address == two.Address {
+		t.Fatal("distinct LOCATION headers produced the same dedup key")
+	}
+}
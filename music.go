@@ -0,0 +1,139 @@
+package yeelight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+//EnterMusicMode switches the device into "music mode": the bulb opens a TCP
+//connection back to us and, from then on, commands are pushed to it one-way
+//over that socket with no reply and no 60/min rate limit. localAddr is the
+//"host:port" to listen on; either part may be empty, in which case the
+//outbound address reachable from the bulb and a free port are chosen.
+func (y *Yeelight) EnterMusicMode(ctx context.Context, localAddr string) error {
+	host, port, err := net.SplitHostPort(localAddr)
+	if err != nil {
+		host, port = "", "0"
+	}
+	if host == "" {
+		if host, err = GetOutboundIP(y.addr); err != nil {
+			return fmt.Errorf("cannot determine outbound address: %s", err)
+		}
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("cannot start music mode listener: %s", err)
+	}
+
+	_, listenPort, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		ln.Close()
+		return fmt.Errorf("cannot determine music mode listener address: %s", err)
+	}
+	portNum, err := strconv.Atoi(listenPort)
+	if err != nil {
+		ln.Close()
+		return fmt.Errorf("cannot parse music mode listener port: %s", err)
+	}
+
+	if _, err := y.executeCommand(ctx, "set_music", []interface{}{1, host, portNum}); err != nil {
+		ln.Close()
+		return fmt.Errorf("cannot enter music mode: %s", err)
+	}
+
+	conn, err := acceptOne(ctx, ln)
+	ln.Close()
+	if err != nil {
+		return fmt.Errorf("bulb did not connect back for music mode: %s", err)
+	}
+
+	y.connMu.Lock()
+	y.musicConn = conn
+	y.connMu.Unlock()
+
+	return nil
+}
+
+//ExitMusicMode tells the device to leave music mode and tears down the
+//reverse connection, if one is active.
+func (y *Yeelight) ExitMusicMode() error {
+	y.connMu.Lock()
+	conn := y.musicConn
+	y.musicConn = nil
+	y.connMu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	_, err := y.executeCommand(context.Background(), "set_music", []interface{}{0})
+	conn.Close()
+	return err
+}
+
+//musicConnection returns the active music-mode connection, if any.
+func (y *Yeelight) musicConnection() net.Conn {
+	y.connMu.Lock()
+	defer y.connMu.Unlock()
+	return y.musicConn
+}
+
+//executeOverMusicConn pushes cmd over the music-mode socket. The bulb never
+//replies to commands sent this way, so a synthetic success result is
+//returned instead of waiting for one.
+func (y *Yeelight) executeOverMusicConn(conn net.Conn, cmd *Command) (*CommandResult, error) {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	y.writeMu.Lock()
+	_, err = fmt.Fprint(conn, string(b)+crlf)
+	y.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("cannot send command to %s. %s", y.addr, err)
+	}
+
+	return &CommandResult{ID: cmd.ID, Result: []interface{}{"ok"}}, nil
+}
+
+//acceptOne waits for exactly one inbound connection on ln, honoring ctx.
+func acceptOne(ctx context.Context, ln net.Listener) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		conn, err := ln.Accept()
+		resCh <- result{conn, err}
+	}()
+
+	select {
+	case r := <-resCh:
+		return r.conn, r.err
+	case <-ctx.Done():
+		ln.Close()
+		<-resCh
+		return nil, ctx.Err()
+	}
+}
+
+//GetOutboundIP returns the local IP address the OS would use to reach
+//target, by opening a throwaway UDP "connection" to it and inspecting the
+//resulting local address. This replaces the old getIP helper, which just
+//grabbed the first non-loopback interface and could easily pick an address
+//unreachable from target's subnet.
+func GetOutboundIP(target string) (string, error) {
+	conn, err := net.Dial("udp", target)
+	if err != nil {
+		return "", fmt.Errorf("cannot determine outbound ip for %s: %s", target, err)
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
@@ -0,0 +1,163 @@
+package yeelight
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+//Prop is a bitmask selecting which properties to request with Props.
+type Prop uint32
+
+//Prop values, one per property get_prop understands.
+const (
+	PropPower Prop = 1 << iota
+	PropBright
+	PropCT
+	PropRGB
+	PropHue
+	PropSat
+	PropColorMode
+	PropFlowing
+	PropDelayOff
+	PropMusicOn
+	PropName
+)
+
+//propNames maps each Prop bit to the protocol's property name, in the
+//fixed order they are requested in.
+var propNames = []struct {
+	prop Prop
+	name string
+}{
+	{PropPower, "power"},
+	{PropBright, "bright"},
+	{PropCT, "ct"},
+	{PropRGB, "rgb"},
+	{PropHue, "hue"},
+	{PropSat, "sat"},
+	{PropColorMode, "color_mode"},
+	{PropFlowing, "flowing"},
+	{PropDelayOff, "delayoff"},
+	{PropMusicOn, "music_on"},
+	{PropName, "name"},
+}
+
+//PropertyMap holds the result of a Props call, keyed by property name, with
+//typed accessors so callers don't have to index []interface{} themselves.
+//The second return value of each accessor reports whether the property was
+//present in the response; the integer and on/off accessors also report false
+//for a value that does not parse as an integer.
+type PropertyMap map[string]interface{}
+
+//Power reports whether the smart LED is switched on.
+func (p PropertyMap) Power() (bool, bool) {
+	v, ok := p["power"].(string)
+	if !ok {
+		return false, false
+	}
+	return v == "on", true
+}
+
+//Brightness reports the brightness percentage (1-100).
+func (p PropertyMap) Brightness() (int, bool) {
+	return p.int("bright")
+}
+
+//ColorTemperature reports the color temperature in degrees Kelvin.
+func (p PropertyMap) ColorTemperature() (int, bool) {
+	return p.int("ct")
+}
+
+//RGB reports the color, packed as 0xRRGGBB.
+func (p PropertyMap) RGB() (int, bool) {
+	return p.int("rgb")
+}
+
+//Hue reports the color hue (0-359).
+func (p PropertyMap) Hue() (int, bool) {
+	return p.int("hue")
+}
+
+//Saturation reports the color saturation (0-100).
+func (p PropertyMap) Saturation() (int, bool) {
+	return p.int("sat")
+}
+
+//ColorMode reports which of rgb/ct/hsv is currently active (1/2/3).
+func (p PropertyMap) ColorMode() (int, bool) {
+	return p.int("color_mode")
+}
+
+//Flowing reports whether a color flow is currently running.
+func (p PropertyMap) Flowing() (bool, bool) {
+	return p.bool01("flowing")
+}
+
+//DelayOff reports the remaining minutes before a scheduled power-off, or 0.
+func (p PropertyMap) DelayOff() (int, bool) {
+	return p.int("delayoff")
+}
+
+//MusicOn reports whether the device is currently in music mode.
+func (p PropertyMap) MusicOn() (bool, bool) {
+	return p.bool01("music_on")
+}
+
+//Name reports the device name stored on the bulb.
+func (p PropertyMap) Name() (string, bool) {
+	v, ok := p["name"].(string)
+	return v, ok
+}
+
+func (p PropertyMap) int(key string) (int, bool) {
+	s, ok := p[key].(string)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func (p PropertyMap) bool01(key string) (bool, bool) {
+	n, ok := p.int(key)
+	if !ok {
+		return false, false
+	}
+	return n == 1, true
+}
+
+//Props retrieves the requested properties, e.g.
+//Props(ctx, PropPower|PropBright), and returns them as a PropertyMap with
+//typed accessors instead of the raw []interface{} GetProp returns.
+func (y *Yeelight) Props(ctx context.Context, props Prop) (PropertyMap, error) {
+	var names []interface{}
+	var keys []string
+	for _, p := range propNames {
+		if props&p.prop == 0 {
+			continue
+		}
+		names = append(names, p.name)
+		keys = append(keys, p.name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no properties requested")
+	}
+
+	r, err := y.executeCommand(ctx, "get_prop", names)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(PropertyMap, len(keys))
+	for i, key := range keys {
+		if i >= len(r.Result) {
+			break
+		}
+		result[key] = r.Result[i]
+	}
+	return result, nil
+}
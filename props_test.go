@@ -0,0 +1,64 @@
+package yeelight
+
+import "testing"
+
+func TestPropertyMapAccessors(t *testing.T) {
+	p := PropertyMap{
+		"power":      "on",
+		"bright":     "80",
+		"ct":         "4000",
+		"rgb":        "16711680",
+		"hue":        "120",
+		"sat":        "50",
+		"color_mode": "2",
+		"flowing":    "1",
+		"delayoff":   "0",
+		"music_on":   "0",
+		"name":       "bedroom",
+	}
+
+	if v, ok := p.Power(); !ok || !v {
+		t.Fatalf("Power() = %v, %v; want true, true", v, ok)
+	}
+	if v, ok := p.Brightness(); !ok || v != 80 {
+		t.Fatalf("Brightness() = %v, %v; want 80, true", v, ok)
+	}
+	if v, ok := p.ColorTemperature(); !ok || v != 4000 {
+		t.Fatalf("ColorTemperature() = %v, %v; want 4000, true", v, ok)
+	}
+	if v, ok := p.RGB(); !ok || v != 16711680 {
+		t.Fatalf("RGB() = %v, %v; want 16711680, true", v, ok)
+	}
+	if v, ok := p.Hue(); !ok || v != 120 {
+		t.Fatalf("Hue() = %v, %v; want 120, true", v, ok)
+	}
+	if v, ok := p.Saturation(); !ok || v != 50 {
+		t.Fatalf("Saturation() = %v, %v; want 50, true", v, ok)
+	}
+	if v, ok := p.ColorMode(); !ok || v != 2 {
+		t.Fatalf("ColorMode() = %v, %v; want 2, true", v, ok)
+	}
+	if v, ok := p.Flowing(); !ok || !v {
+		t.Fatalf("Flowing() = %v, %v; want true, true", v, ok)
+	}
+	if v, ok := p.DelayOff(); !ok || v != 0 {
+		t.Fatalf("DelayOff() = %v, %v; want 0, true", v, ok)
+	}
+	if v, ok := p.MusicOn(); !ok || v {
+		t.Fatalf("MusicOn() = %v, %v; want false, true", v, ok)
+	}
+	if v, ok := p.Name(); !ok || v != "bedroom" {
+		t.Fatalf("Name() = %v, %v; want bedroom, true", v, ok)
+	}
+}
+
+func TestPropertyMapAccessorsMissingKey(t *testing.T) {
+	p := PropertyMap{}
+
+	if v, ok := p.Brightness(); ok || v != 0 {
+		t.Fatalf("Brightness() on empty map = %v, %v; want 0, false", v, ok)
+	}
+	if v, ok := p.Power(); ok || v {
+		t.Fatalf("Power() on empty map = %v, %v; want false, false", v, ok)
+	}
+}